@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import "strings"
+
+// Exec runs cmd inside container of pod via `kubectl exec`, returning the
+// command's combined output. container may be empty for single-container pods.
+func Exec(runner KubeRunner, pod string, namespace string, container string, cmd []string) (string, error) {
+	args := []string{"exec", pod}
+	if len(container) > 0 {
+		args = append(args, "-c", container)
+	}
+	args = append(args, "--")
+	args = append(args, cmd...)
+	return runner.Run(args, namespace, "")
+}
+
+// SignalContainer sends signal to PID 1 of container inside pod, via
+// `kubectl exec -- kill`, so the kubelet observes the container exiting and
+// restarts it
+func SignalContainer(runner KubeRunner, pod string, namespace string, container string, signal string) error {
+	_, err := Exec(runner, pod, namespace, container, []string{"kill", "-s", signal, "1"})
+	return err
+}
+
+// BlockTraffic partitions pod away from any peer matching peerLabels by
+// inserting an iptables DROP rule, inside pod, targeting each peer's IP
+func BlockTraffic(runner KubeRunner, pod string, namespace string, peerLabels string) error {
+	return iptablesDrop(runner, pod, namespace, peerLabels, "-I")
+}
+
+// UnblockTraffic removes the iptables DROP rule previously injected by
+// BlockTraffic, restoring traffic between pod and peers matching peerLabels
+func UnblockTraffic(runner KubeRunner, pod string, namespace string, peerLabels string) error {
+	return iptablesDrop(runner, pod, namespace, peerLabels, "-D")
+}
+
+// iptablesDrop is shared plumbing for BlockTraffic & UnblockTraffic; chainOp
+// is the iptables rule-management flag e.g. "-I" to insert, "-D" to delete
+func iptablesDrop(runner KubeRunner, pod string, namespace string, peerLabels string, chainOp string) error {
+	peerIPs, err := podIPsForLabels(runner, namespace, peerLabels)
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range peerIPs {
+		cmd := []string{"exec", pod, "--", "iptables", chainOp, "OUTPUT", "-d", ip, "-j", "DROP"}
+		if _, err := runner.Run(cmd, namespace, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// podIPsForLabels resolves the running pod IPs matching labels, used to
+// build the iptables rules that partition traffic toward them
+func podIPsForLabels(runner KubeRunner, namespace string, labels string) ([]string, error) {
+	out, err := runner.Run([]string{"get", "pods", "-l", labels, "-o", "jsonpath={.items[*].status.podIP}"}, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodContainerImages carries the running image reference of every container
+// in a single pod, as seen by GetPodContainerImages
+type PodContainerImages struct {
+	// PodName the containers below belong to
+	PodName string
+	// Containers running inside PodName
+	Containers []ContainerImage
+}
+
+// ContainerImage pairs a container name with its running image reference
+type ContainerImage struct {
+	// Name of the container
+	Name string
+	// Image reference the container is currently running
+	Image string
+}
+
+// GetPodContainerImages returns the running image reference of every
+// container in every pod matching namespace & labels
+func GetPodContainerImages(runner KubeRunner, namespace string, labels string) ([]PodContainerImages, error) {
+	out, err := runner.Run([]string{"get", "pods", "-l", labels, "-o", "json"}, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var podList corev1.PodList
+	if err := json.Unmarshal([]byte(out), &podList); err != nil {
+		return nil, fmt.Errorf("failed to parse pod container images: %s", err.Error())
+	}
+
+	images := make([]PodContainerImages, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		pi := PodContainerImages{PodName: pod.Name}
+		for _, cs := range pod.Status.ContainerStatuses {
+			pi.Containers = append(pi.Containers, ContainerImage{Name: cs.Name, Image: cs.Image})
+		}
+		images = append(images, pi)
+	}
+	return images, nil
+}
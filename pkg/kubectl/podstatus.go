@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodStatus is a pod-level status snapshot returned by GetPodStatuses
+type PodStatus struct {
+	// PodName the status below belongs to
+	PodName string
+	// Phase of the pod e.g. "Running", "Pending"
+	Phase string
+	// Containers running inside PodName
+	Containers []ContainerStatus
+}
+
+// ContainerStatus is a container-level status snapshot returned by
+// GetPodStatuses
+type ContainerStatus struct {
+	// Name of the container
+	Name string
+	// Image reference the container is currently running
+	Image string
+	// Ready reports the container's readiness probe result
+	Ready bool
+	// RestartCount of the container so far
+	RestartCount int32
+	// WaitingReason is set when the container is currently waiting e.g.
+	// "CrashLoopBackOff"; empty otherwise
+	WaitingReason string
+}
+
+// GetPodStatuses returns a pod & container level status snapshot for every
+// pod matching namespace & labels
+func GetPodStatuses(runner KubeRunner, namespace string, labels string) ([]PodStatus, error) {
+	out, err := runner.Run([]string{"get", "pods", "-l", labels, "-o", "json"}, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var podList corev1.PodList
+	if err := json.Unmarshal([]byte(out), &podList); err != nil {
+		return nil, fmt.Errorf("failed to parse pod statuses: %s", err.Error())
+	}
+
+	statuses := make([]PodStatus, 0, len(podList.Items))
+	for _, pod := range podList.Items {
+		ps := PodStatus{PodName: pod.Name, Phase: string(pod.Status.Phase)}
+		for _, cs := range pod.Status.ContainerStatuses {
+			waitingReason := ""
+			if cs.State.Waiting != nil {
+				waitingReason = cs.State.Waiting.Reason
+			}
+			ps.Containers = append(ps.Containers, ContainerStatus{
+				Name:          cs.Name,
+				Image:         cs.Image,
+				Ready:         cs.Ready,
+				RestartCount:  cs.RestartCount,
+				WaitingReason: waitingReason,
+			})
+		}
+		statuses = append(statuses, ps)
+	}
+	return statuses, nil
+}
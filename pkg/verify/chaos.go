@@ -0,0 +1,349 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AmitKumarDas/litmus/pkg/kubectl"
+)
+
+const (
+	// NetworkPartitionAction partitions network traffic between the target
+	// component and one or more peer aliases
+	NetworkPartitionAction Action = "network-partition"
+	// StressCPUAction pegs CPU usage inside the target component's container
+	StressCPUAction Action = "stress-cpu"
+	// StressMemoryAction pegs memory usage inside the target component's container
+	StressMemoryAction Action = "stress-memory"
+	// DiskFillAction fills up a configurable percentage of the target component's
+	// mounted volume
+	DiskFillAction Action = "disk-fill"
+	// KillProcessAction kills a named process running inside the target
+	// component's container
+	KillProcessAction Action = "kill-process"
+	// RestartContainerAction sends SIGKILL to the target component's container
+	// so that the kubelet restarts it
+	RestartContainerAction Action = "restart-container"
+
+	// defaultPostConditionTimeoutSeconds is how long IsActionWithRollback waits
+	// for a component to return to Running after a chaos action completes, when
+	// the component itself has not configured a DurationSeconds
+	defaultPostConditionTimeoutSeconds = 60
+
+	// defaultMountPath is the path disk-fill targets when a component's
+	// ChaosParams does not configure MountPath
+	defaultMountPath = "/mnt/data"
+)
+
+// ChaosParams carries the action specific parameters required to run a chaos
+// action against a component. It is loaded alongside the component's alias
+// from the Installation YAML.
+type ChaosParams struct {
+	// DurationSeconds is how long the action should run before it is
+	// considered complete e.g. duration of a stress-ng run
+	DurationSeconds int `json:"durationSeconds"`
+	// PercentLoad is the amount of load to apply e.g. percentage of CPU,
+	// memory or disk to consume
+	PercentLoad int `json:"percentLoad"`
+	// TargetProcess is the name of the process to act on e.g. the process
+	// name passed to kill-process
+	TargetProcess string `json:"targetProcess"`
+	// TargetAlias is the alias of the peer component to partition away from,
+	// used by network-partition
+	TargetAlias string `json:"targetAlias"`
+	// MountPath is the path, inside the target container, of the mounted PV
+	// that disk-fill should fill up. Defaults to defaultMountPath.
+	MountPath string `json:"mountPath"`
+}
+
+// RollbackHandle is returned by a chaos action so callers can guarantee
+// cleanup of the action's side effects e.g. when a test times out
+type RollbackHandle interface {
+	// Rollback undoes the side effects caused by the chaos action
+	Rollback() error
+}
+
+// rollbackFunc adapts a plain function into a RollbackHandle
+type rollbackFunc func() error
+
+// Rollback undoes the side effects caused by the chaos action
+func (f rollbackFunc) Rollback() error {
+	if f == nil {
+		return nil
+	}
+	return f()
+}
+
+// noopRollback is a RollbackHandle that does nothing; used when an action has
+// no side effect that needs reverting
+var noopRollback RollbackHandle = rollbackFunc(nil)
+
+// IsActionWithRollback evaluates if the component identified by alias
+// satisfies the provided chaos action and returns a RollbackHandle that the
+// caller must invoke to guarantee cleanup, regardless of whether the
+// post-condition check below eventually succeeds
+func (v *KubeInstallVerify) IsActionWithRollback(alias string, action Action) (rollback RollbackHandle, err error) {
+	c, err := v.getMatchingPodComponent(alias)
+	if err != nil {
+		return
+	}
+
+	params, err := v.chaosParams(c)
+	if err != nil {
+		return
+	}
+
+	var run func(Component, ChaosParams) (RollbackHandle, error)
+
+	switch action {
+	case NetworkPartitionAction:
+		run = v.runNetworkPartition
+	case StressCPUAction:
+		run = v.runStressCPU
+	case StressMemoryAction:
+		run = v.runStressMemory
+	case DiskFillAction:
+		run = v.runDiskFill
+	case KillProcessAction:
+		run = v.runKillProcess
+	case RestartContainerAction:
+		run = v.runRestartContainer
+	default:
+		err = fmt.Errorf("action '%s' is not supported", action)
+		return
+	}
+
+	rollback, err = run(c, params)
+	if err != nil {
+		return
+	}
+
+	if perr := v.postConditionPodRunning(c, params.DurationSeconds); perr != nil {
+		err = fmt.Errorf("action '%s' post condition failed for alias '%s': %s", action, alias, perr.Error())
+	}
+	return
+}
+
+// runNetworkPartition blocks traffic between the component and its configured
+// TargetAlias by injecting iptables rules into the target pod
+func (v *KubeInstallVerify) runNetworkPartition(c Component, params ChaosParams) (RollbackHandle, error) {
+	if len(params.TargetAlias) == 0 {
+		return nil, fmt.Errorf("unable to run network-partition on alias '%s': targetAlias is required", c.Alias)
+	}
+
+	peer, err := v.getMatchingPodComponent(params.TargetAlias)
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(peer.Labels)) == 0 {
+		return nil, fmt.Errorf("unable to run network-partition on alias '%s': peer alias '%s' is missing labels", c.Alias, params.TargetAlias)
+	}
+
+	pods, err := kubectl.GetRunningPods(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("failed to run network-partition: no running pods found for alias '%s'", c.Alias)
+	}
+
+	for _, pod := range pods {
+		if err := kubectl.BlockTraffic(v.kubectl, pod, c.Namespace, peer.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return rollbackFunc(func() error {
+		var lastErr error
+		for _, pod := range pods {
+			if err := kubectl.UnblockTraffic(v.kubectl, pod, c.Namespace, peer.Labels); err != nil {
+				lastErr = err
+			}
+		}
+		return lastErr
+	}), nil
+}
+
+// runStressCPU runs a stress-ng CPU load inside the target component's
+// container for the configured duration
+func (v *KubeInstallVerify) runStressCPU(c Component, params ChaosParams) (RollbackHandle, error) {
+	return v.runExecStress(c, params, "stress-ng", []string{"--cpu", "0", "--cpu-load"})
+}
+
+// runStressMemory runs a stress-ng memory load inside the target component's
+// container for the configured duration
+func (v *KubeInstallVerify) runStressMemory(c Component, params ChaosParams) (RollbackHandle, error) {
+	return v.runExecStress(c, params, "stress-ng", []string{"--vm", "1", "--vm-bytes"})
+}
+
+// runExecStress is shared plumbing for the stress-ng based chaos actions.
+// loadArgs are the stressor-selection flags, ending in the flag that takes
+// PercentLoad as its value e.g. "--cpu-load" for CPU, "--vm-bytes" for memory
+func (v *KubeInstallVerify) runExecStress(c Component, params ChaosParams, binary string, loadArgs []string) (RollbackHandle, error) {
+	pods, err := kubectl.GetRunningPods(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("failed to stress alias '%s': no running pods found", c.Alias)
+	}
+
+	cmd := append([]string{binary}, loadArgs...)
+	cmd = append(cmd, fmt.Sprintf("%d%%", params.PercentLoad), "--timeout", fmt.Sprintf("%ds", params.DurationSeconds))
+	for _, pod := range pods {
+		if _, err := kubectl.Exec(v.kubectl, pod, c.Namespace, c.Container, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return noopRollback, nil
+}
+
+// runDiskFill allocates PercentLoad percent of the mounted volume inside the
+// target component's container
+func (v *KubeInstallVerify) runDiskFill(c Component, params ChaosParams) (RollbackHandle, error) {
+	pods, err := kubectl.GetRunningPods(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("failed to disk-fill alias '%s': no running pods found", c.Alias)
+	}
+
+	mountPath, fillFile := diskFillTarget(params)
+	cmd := diskFillCommand(mountPath, fillFile, params.PercentLoad)
+	for _, pod := range pods {
+		if _, err := kubectl.Exec(v.kubectl, pod, c.Namespace, c.Container, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return rollbackFunc(func() error {
+		var lastErr error
+		for _, pod := range pods {
+			if _, err := kubectl.Exec(v.kubectl, pod, c.Namespace, c.Container, []string{"rm", "-f", fillFile}); err != nil {
+				lastErr = err
+			}
+		}
+		return lastErr
+	}), nil
+}
+
+// diskFillTarget resolves the mount path disk-fill should target, defaulting
+// to defaultMountPath when params does not configure one, along with the
+// path of the file disk-fill writes into that mount
+func diskFillTarget(params ChaosParams) (mountPath string, fillFile string) {
+	mountPath = params.MountPath
+	if len(mountPath) == 0 {
+		mountPath = defaultMountPath
+	}
+	fillFile = fmt.Sprintf("%s/litmus-disk-fill", strings.TrimRight(mountPath, "/"))
+	return
+}
+
+// diskFillCommand builds the shell command that writes percentLoad percent of
+// mountPath's available space into fillFile
+func diskFillCommand(mountPath string, fillFile string, percentLoad int) []string {
+	return []string{"sh", "-c", fmt.Sprintf("dd if=/dev/zero of=%s bs=1M count=$(df --output=avail %s | tail -1 | awk '{print int($1/1024*%d/100)}')", fillFile, mountPath, percentLoad)}
+}
+
+// runKillProcess kills the configured TargetProcess inside the target
+// component's container
+func (v *KubeInstallVerify) runKillProcess(c Component, params ChaosParams) (RollbackHandle, error) {
+	if len(params.TargetProcess) == 0 {
+		return nil, fmt.Errorf("unable to run kill-process on alias '%s': targetProcess is required", c.Alias)
+	}
+
+	pods, err := kubectl.GetRunningPods(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("failed to kill-process on alias '%s': no running pods found", c.Alias)
+	}
+
+	for _, pod := range pods {
+		cmd := []string{"pkill", "-9", params.TargetProcess}
+		if _, err := kubectl.Exec(v.kubectl, pod, c.Namespace, c.Container, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return noopRollback, nil
+}
+
+// runRestartContainer sends SIGKILL to the target component's container so
+// the kubelet restarts it
+func (v *KubeInstallVerify) runRestartContainer(c Component, params ChaosParams) (RollbackHandle, error) {
+	pods, err := kubectl.GetRunningPods(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("failed to restart-container on alias '%s': no running pods found", c.Alias)
+	}
+
+	for _, pod := range pods {
+		if err := kubectl.SignalContainer(v.kubectl, pod, c.Namespace, c.Container, "SIGKILL"); err != nil {
+			return nil, err
+		}
+	}
+
+	return noopRollback, nil
+}
+
+// chaosParams returns the component's ChaosParams, defaulting DurationSeconds
+// when the component did not configure one
+func (v *KubeInstallVerify) chaosParams(c Component) (ChaosParams, error) {
+	if c.ChaosParams == nil {
+		return ChaosParams{DurationSeconds: defaultPostConditionTimeoutSeconds}, nil
+	}
+
+	p := *c.ChaosParams
+	if p.DurationSeconds <= 0 {
+		p.DurationSeconds = defaultPostConditionTimeoutSeconds
+	}
+	return p, nil
+}
+
+// postConditionPodRunning polls until the component's pods return to Running
+// or timeoutSeconds elapses, which is the action's own resolved
+// DurationSeconds so a long running stress/disk-fill action is not flagged
+// as a spurious failure while it is still legitimately running
+func (v *KubeInstallVerify) postConditionPodRunning(c Component, timeoutSeconds int) error {
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	deadline := time.Now().Add(timeout)
+
+	for {
+		yes, err := v.isPodComponentRunning(c)
+		if err == nil && yes {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("component '%s' did not return to running state within %s", c.Alias, timeout)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
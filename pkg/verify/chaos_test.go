@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChaosParams(t *testing.T) {
+	v := &KubeInstallVerify{}
+
+	tests := map[string]struct {
+		component Component
+		want      ChaosParams
+	}{
+		"nil ChaosParams defaults duration": {
+			component: Component{},
+			want:      ChaosParams{DurationSeconds: defaultPostConditionTimeoutSeconds},
+		},
+		"zero duration defaults": {
+			component: Component{ChaosParams: &ChaosParams{PercentLoad: 50}},
+			want:      ChaosParams{DurationSeconds: defaultPostConditionTimeoutSeconds, PercentLoad: 50},
+		},
+		"negative duration defaults": {
+			component: Component{ChaosParams: &ChaosParams{DurationSeconds: -5}},
+			want:      ChaosParams{DurationSeconds: defaultPostConditionTimeoutSeconds},
+		},
+		"explicit values are preserved": {
+			component: Component{ChaosParams: &ChaosParams{DurationSeconds: 30, PercentLoad: 80, MountPath: "/data"}},
+			want:      ChaosParams{DurationSeconds: 30, PercentLoad: 80, MountPath: "/data"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := v.chaosParams(tt.component)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDiskFillTarget(t *testing.T) {
+	tests := map[string]struct {
+		params       ChaosParams
+		wantMount    string
+		wantFillFile string
+	}{
+		"default mount path when unset": {
+			params:       ChaosParams{},
+			wantMount:    defaultMountPath,
+			wantFillFile: defaultMountPath + "/litmus-disk-fill",
+		},
+		"configured mount path": {
+			params:       ChaosParams{MountPath: "/data"},
+			wantMount:    "/data",
+			wantFillFile: "/data/litmus-disk-fill",
+		},
+		"configured mount path with trailing slash": {
+			params:       ChaosParams{MountPath: "/data/"},
+			wantMount:    "/data/",
+			wantFillFile: "/data/litmus-disk-fill",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mountPath, fillFile := diskFillTarget(tt.params)
+			if mountPath != tt.wantMount {
+				t.Fatalf("expected mountPath %q, got %q", tt.wantMount, mountPath)
+			}
+			if fillFile != tt.wantFillFile {
+				t.Fatalf("expected fillFile %q, got %q", tt.wantFillFile, fillFile)
+			}
+		})
+	}
+}
+
+func TestDiskFillCommand(t *testing.T) {
+	cmd := diskFillCommand("/data", "/data/litmus-disk-fill", 50)
+
+	if len(cmd) != 3 || cmd[0] != "sh" || cmd[1] != "-c" {
+		t.Fatalf("expected a 3 element sh -c command, got %+v", cmd)
+	}
+	script := cmd[2]
+	if !strings.Contains(script, "/data/litmus-disk-fill") {
+		t.Fatalf("expected script to reference the fill file, got %q", script)
+	}
+	if !strings.Contains(script, "/data") {
+		t.Fatalf("expected script to reference the mount path, got %q", script)
+	}
+	if !strings.Contains(script, "50/100") {
+		t.Fatalf("expected script to reference the configured percent load, got %q", script)
+	}
+}
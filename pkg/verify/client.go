@@ -0,0 +1,400 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+)
+
+// informerResyncPeriod controls how often the shared informer cache
+// re-lists from the apiserver
+const informerResyncPeriod = 5 * time.Minute
+
+// RESTConfigProvider is optionally implemented by a kubectl.KubeRunner to
+// expose the underlying rest.Config. When a runner implements this and the
+// client can be built from its config, NewKubeInstallVerify builds a
+// KubeClientVerifier under the hood and delegates verification calls to it
+// instead of shelling out to kubectl. IsActionWithRollback is not delegated
+// and always runs through kubectl.
+type RESTConfigProvider interface {
+	RESTConfig() (*rest.Config, error)
+}
+
+// KubeClientVerifier provides the same verification contracts as
+// KubeInstallVerify but is implemented against k8s.io/client-go instead of
+// forking kubectl. Pod queries use the typed clientset backed by a shared
+// informer cache; arbitrary CRD Kinds declared via Component.Kind/APIVersion
+// are resolved through the dynamic client and a discovery-backed RESTMapper.
+type KubeClientVerifier struct {
+	// installation is the set of components that determine the install
+	installation *Installation
+	// client is the typed kubernetes clientset
+	client kubernetes.Interface
+	// dynamicClient handles arbitrary Kinds that are not part of the typed
+	// clientset e.g. CRDs
+	dynamicClient dynamic.Interface
+	// mapper resolves a Kind/APIVersion into a GroupVersionResource
+	mapper meta.RESTMapper
+	// informerFactory backs the shared pod informer cache so repeated
+	// verifications against the same installation do not re-list
+	informerFactory informers.SharedInformerFactory
+	// podLister serves cached pod reads once the informer cache has synced
+	podLister corelisters.PodLister
+	// podInformerSynced reports whether the pod informer cache has synced
+	podInformerSynced cache.InformerSynced
+}
+
+// NewKubeClientVerifier provides a new instance of KubeClientVerifier based
+// on the provided typed & dynamic clients, RESTMapper & verify file
+func NewKubeClientVerifier(client kubernetes.Interface, dynamicClient dynamic.Interface, mapper meta.RESTMapper, file VerifyFile) (*KubeClientVerifier, error) {
+	i, err := load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKubeClientVerifier(client, dynamicClient, mapper, i)
+}
+
+// newKubeClientVerifier wires up the shared informer cache against an
+// already loaded Installation
+func newKubeClientVerifier(client kubernetes.Interface, dynamicClient dynamic.Interface, mapper meta.RESTMapper, i *Installation) (*KubeClientVerifier, error) {
+	factory := informers.NewSharedInformerFactory(client, informerResyncPeriod)
+	podInformer := factory.Core().V1().Pods()
+
+	v := &KubeClientVerifier{
+		installation:      i,
+		client:            client,
+		dynamicClient:     dynamicClient,
+		mapper:            mapper,
+		informerFactory:   factory,
+		podLister:         podInformer.Lister(),
+		podInformerSynced: podInformer.Informer().HasSynced,
+	}
+
+	stop := make(chan struct{})
+	factory.Start(stop)
+	if !cache.WaitForCacheSync(stop, v.podInformerSynced) {
+		return nil, fmt.Errorf("failed to sync pod informer cache")
+	}
+
+	return v, nil
+}
+
+// newKubeClientVerifierFromConfig builds a KubeClientVerifier straight from a
+// rest.Config, resolving the typed clientset, dynamic client & a
+// discovery-backed RESTMapper
+func newKubeClientVerifierFromConfig(cfg *rest.Config, i *Installation) (*KubeClientVerifier, error) {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(client.Discovery())
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	return newKubeClientVerifier(client, dynamicClient, mapper, i)
+}
+
+// IsDeployed evaluates if all components of the installation are deployed
+func (v *KubeClientVerifier) IsDeployed() (yes bool, err error) {
+	if v.installation == nil {
+		err = fmt.Errorf("failed to check IsDeployed: installation object is nil")
+		return
+	}
+
+	for _, c := range v.installation.Components {
+		yes, err = v.isComponentDeployed(c)
+		if err != nil || !yes {
+			return
+		}
+	}
+	return
+}
+
+// IsRunning evaluates if all pod components of the installation are running
+func (v *KubeClientVerifier) IsRunning() (yes bool, err error) {
+	if v.installation == nil {
+		err = fmt.Errorf("failed to check IsRunning: installation object is nil")
+		return
+	}
+
+	for _, c := range v.installation.Components {
+		yes, err = v.isPodComponentRunning(c)
+		if err != nil || !yes {
+			return
+		}
+	}
+	return
+}
+
+// isComponentDeployed resolves the component via the typed clientset for
+// core Kinds, or the dynamic client + RESTMapper for everything else
+func (v *KubeClientVerifier) isComponentDeployed(c Component) (bool, error) {
+	if kubectlIsPod(c.Kind) {
+		pods, err := v.listPods(c)
+		if err != nil {
+			return false, err
+		}
+		return len(pods) > 0, nil
+	}
+
+	gvr, err := v.resourceFor(c)
+	if err != nil {
+		return false, err
+	}
+
+	list, err := v.dynamicClient.Resource(gvr).Namespace(c.Namespace).List(metav1.ListOptions{LabelSelector: c.Labels})
+	if err != nil {
+		return false, err
+	}
+	return len(list.Items) > 0, nil
+}
+
+// isPodComponentRunning flags if a particular component's pods are all
+// Running, reading from the shared informer cache
+func (v *KubeClientVerifier) isPodComponentRunning(c Component) (bool, error) {
+	if !kubectlIsPod(c.Kind) {
+		return true, nil
+	}
+
+	pods, err := v.listPods(c)
+	if err != nil {
+		return false, err
+	}
+	if len(pods) == 0 {
+		return false, fmt.Errorf("no pods found for alias '%s'", c.Alias)
+	}
+
+	for _, pod := range pods {
+		if pod.Status.Phase != "Running" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// listPods lists the pods matching a component's namespace & labels from the
+// shared informer cache
+func (v *KubeClientVerifier) listPods(c Component) ([]*corev1.Pod, error) {
+	selector, err := labels.Parse(c.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels '%s' for alias '%s': %s", c.Labels, c.Alias, err.Error())
+	}
+
+	return v.podLister.Pods(c.Namespace).List(selector)
+}
+
+// IsCondition evaluates if the component identified by alias satisfies the
+// condition, reusing the same Condition vocabulary as KubeInstallVerify
+func (v *KubeClientVerifier) IsCondition(alias string, condition Condition) (yes bool, err error) {
+	switch {
+	case condition == UniqueNodeCond:
+		return v.isEachComponentOnUniqueNode(alias)
+	case condition == ImageVersionMatchCond:
+		return v.isImageVersionMatch(alias)
+	case isPodAssertionCondition(condition):
+		return v.isPodAssertion(alias, condition)
+	default:
+		err = fmt.Errorf("condition '%s' is not supported", condition)
+	}
+	return
+}
+
+// IsAction evaluates if the component identified by alias satisfies the
+// action, reusing the same Action vocabulary as KubeInstallVerify
+func (v *KubeClientVerifier) IsAction(alias string, action Action) (yes bool, err error) {
+	switch action {
+	case DeleteAnyPodAction, DeleteOldestPodAction:
+		return v.deleteRunningPod(alias, action)
+	default:
+		err = fmt.Errorf("action '%s' is not supported", action)
+	}
+	return
+}
+
+// IsActionWithRollback is not yet supported against the client-go backed
+// verifier; the chaos-action suite currently requires the exec/iptables
+// plumbing that only the kubectl.KubeRunner path provides
+func (v *KubeClientVerifier) IsActionWithRollback(alias string, action Action) (rollback RollbackHandle, err error) {
+	err = fmt.Errorf("action '%s' with rollback is not supported against the client-go verifier", action)
+	return
+}
+
+// deleteRunningPod deletes either any running pod, or the oldest running pod,
+// matching alias
+func (v *KubeClientVerifier) deleteRunningPod(alias string, action Action) (bool, error) {
+	c, err := v.componentForAlias(alias)
+	if err != nil {
+		return false, err
+	}
+
+	pods, err := v.listPods(c)
+	if err != nil {
+		return false, err
+	}
+	var running []*corev1.Pod
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodRunning {
+			running = append(running, p)
+		}
+	}
+	pods = running
+	if len(pods) == 0 {
+		return false, fmt.Errorf("failed to delete pod: no running pods found for alias '%s'", alias)
+	}
+
+	target := pods[0]
+	if action == DeleteOldestPodAction {
+		for _, p := range pods {
+			if p.CreationTimestamp.Before(&target.CreationTimestamp) {
+				target = p
+			}
+		}
+	}
+
+	err = v.client.CoreV1().Pods(c.Namespace).Delete(target.Name, &metav1.DeleteOptions{})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// componentForAlias returns the single pod component matching alias
+func (v *KubeClientVerifier) componentForAlias(alias string) (Component, error) {
+	var filtered []Component
+	for _, c := range v.installation.Components {
+		if c.Alias == alias && kubectlIsPod(c.Kind) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return Component{}, fmt.Errorf("component not found for alias '%s'", alias)
+	}
+	if len(filtered) > 1 {
+		return Component{}, fmt.Errorf("multiple components found for alias '%s': alias should be unique in an install", alias)
+	}
+	return filtered[0], nil
+}
+
+// componentForAnyKind returns the single component matching alias,
+// regardless of Kind, mirroring KubeInstallVerify.getMatchingComponent
+func (v *KubeClientVerifier) componentForAnyKind(alias string) (Component, error) {
+	var filtered []Component
+	for _, c := range v.installation.Components {
+		if c.Alias == alias {
+			filtered = append(filtered, c)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return Component{}, fmt.Errorf("component not found for alias '%s'", alias)
+	}
+	if len(filtered) > 1 {
+		return Component{}, fmt.Errorf("multiple components found for alias '%s': alias should be unique in an install", alias)
+	}
+	return filtered[0], nil
+}
+
+// isEachComponentOnUniqueNode flags if each pod component matching alias is
+// placed on a unique node
+func (v *KubeClientVerifier) isEachComponentOnUniqueNode(alias string) (bool, error) {
+	var nodes []string
+
+	for _, c := range v.installation.Components {
+		if c.Alias != alias || !kubectlIsPod(c.Kind) {
+			continue
+		}
+
+		pods, err := v.listPods(c)
+		if err != nil {
+			return false, err
+		}
+		for _, p := range pods {
+			nodes = append(nodes, p.Spec.NodeName)
+		}
+	}
+
+	exists := map[string]bool{}
+	for _, n := range nodes {
+		if exists[n] {
+			return false, nil
+		}
+		exists[n] = true
+	}
+	return true, nil
+}
+
+// resourceFor resolves a Component's Kind/APIVersion into a
+// GroupVersionResource using the discovery-backed RESTMapper. Component.Kind
+// follows the documented verify-file convention of lowercase/plural resource
+// names (e.g. "pods", "deployments"), matching what the legacy kubectl path
+// accepted, so this resolves via RESTMapper.ResourceFor against a partial
+// GroupVersionResource rather than RESTMapping, which expects a proper
+// PascalCase singular API Kind.
+func (v *KubeClientVerifier) resourceFor(c Component) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(c.APIVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid apiVersion '%s' for alias '%s': %s", c.APIVersion, c.Alias, err.Error())
+	}
+
+	gvr, err := v.mapper.ResourceFor(schema.GroupVersionResource{Group: gv.Group, Version: gv.Version, Resource: c.Kind})
+	if err != nil {
+		return schema.GroupVersionResource{}, fmt.Errorf("failed to resolve kind '%s' for alias '%s': %s", c.Kind, c.Alias, err.Error())
+	}
+
+	return gvr, nil
+}
+
+// unstructuredListFor returns the raw unstructured objects matching a
+// component's namespace & labels, listed through the dynamic client
+func (v *KubeClientVerifier) unstructuredListFor(c Component) (*unstructured.UnstructuredList, error) {
+	gvr, err := v.resourceFor(c)
+	if err != nil {
+		return nil, err
+	}
+	return v.dynamicClient.Resource(gvr).Namespace(c.Namespace).List(metav1.ListOptions{LabelSelector: c.Labels})
+}
+
+// kubectlIsPod mirrors kubectl.IsPod without requiring a kubectl.KubeRunner
+func kubectlIsPod(kind string) bool {
+	return strings.EqualFold(kind, "pod") || strings.EqualFold(kind, "pods")
+}
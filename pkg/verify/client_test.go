@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newTestRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "", Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	return mapper
+}
+
+func TestResourceFor(t *testing.T) {
+	v := &KubeClientVerifier{mapper: newTestRESTMapper()}
+
+	tests := map[string]struct {
+		component Component
+		want      schema.GroupVersionResource
+		wantErr   bool
+	}{
+		"core kind resolves via lowercase plural": {
+			component: Component{Kind: "pods", APIVersion: "v1"},
+			want:      schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"},
+		},
+		"grouped kind resolves via lowercase plural": {
+			component: Component{Kind: "deployments", APIVersion: "apps/v1"},
+			want:      schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		},
+		"invalid apiVersion errors": {
+			component: Component{Kind: "pods", APIVersion: "apps/v1/extra/bad"},
+			wantErr:   true,
+		},
+		"unknown kind errors": {
+			component: Component{Kind: "widgets", APIVersion: "v1"},
+			wantErr:   true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := v.resourceFor(tt.component)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
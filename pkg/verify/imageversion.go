@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/AmitKumarDas/litmus/pkg/kubectl"
+)
+
+const (
+	// ImageVersionMatchCond is a condition to check that the running image
+	// tag of a component matches its expected Component.Version
+	ImageVersionMatchCond Condition = "image-version-match"
+)
+
+// containerImage captures where a running container image was found, so a
+// mismatch error can point the user at the exact pod & container
+type containerImage struct {
+	PodName       string
+	ContainerName string
+	Image         string
+}
+
+// imageVersionTag extracts the version tag out of an image reference,
+// stripping any registry prefix & any trailing "@sha256:..." digest
+func imageVersionTag(image string) string {
+	// drop any digest suffix e.g. "nginx:1.2.3@sha256:abcd..."
+	if idx := strings.Index(image, "@"); idx != -1 {
+		image = image[:idx]
+	}
+
+	// the tag is whatever follows the last colon that occurs after the last
+	// slash, so a registry:port prefix is not mistaken for a tag separator
+	lastSlash := strings.LastIndex(image, "/")
+	ref := image
+	if lastSlash != -1 {
+		ref = image[lastSlash+1:]
+	}
+
+	lastColon := strings.LastIndex(ref, ":")
+	if lastColon == -1 {
+		return ""
+	}
+	return ref[lastColon+1:]
+}
+
+// matchImageVersion compares the found image tag against an expected
+// version, preferring semver comparison & falling back to string equality
+// when either side fails to parse as semver
+func matchImageVersion(found string, expected string) bool {
+	foundVer, ferr := semver.NewVersion(found)
+	expectedVer, eerr := semver.NewVersion(expected)
+	if ferr == nil && eerr == nil {
+		return foundVer.Equal(expectedVer)
+	}
+	return found == expected
+}
+
+// verifyImageVersions returns a structured error describing every container
+// whose running image tag does not match the component's expected Version
+func verifyImageVersions(images []containerImage, expectedVersion string) error {
+	var mismatches []string
+
+	for _, ci := range images {
+		found := imageVersionTag(ci.Image)
+		if matchImageVersion(found, expectedVersion) {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("pod '%s' container '%s': expected version '%s', found '%s' (image '%s')", ci.PodName, ci.ContainerName, expectedVersion, found, ci.Image))
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("image-version-match failed:\n%s", strings.Join(mismatches, "\n"))
+	}
+	return nil
+}
+
+// isImageVersionMatch resolves the live pods for alias via kubectl & checks
+// each container's running image tag against the component's Version. This
+// works for any Kind whose pods are reachable via the component's label
+// selector, not just bare Pod components, e.g. Deployments, StatefulSets &
+// DaemonSets whose pod templates carry the same labels.
+func (v *KubeInstallVerify) isImageVersionMatch(alias string) (yes bool, err error) {
+	c, err := v.getMatchingComponent(alias)
+	if err != nil {
+		return
+	}
+
+	if len(strings.TrimSpace(c.Version)) == 0 {
+		err = fmt.Errorf("unable to verify image-version-match for alias '%s': component version is required", alias)
+		return
+	}
+
+	podImages, err := kubectl.GetPodContainerImages(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return
+	}
+	if len(podImages) == 0 {
+		err = fmt.Errorf("unable to verify image-version-match for alias '%s': no running pods found", alias)
+		return
+	}
+
+	images := make([]containerImage, 0, len(podImages))
+	for _, pi := range podImages {
+		for _, ci := range pi.Containers {
+			if len(c.Container) > 0 && ci.Name != c.Container {
+				continue
+			}
+			images = append(images, containerImage{PodName: pi.PodName, ContainerName: ci.Name, Image: ci.Image})
+		}
+	}
+
+	if err = verifyImageVersions(images, c.Version); err != nil {
+		return
+	}
+	yes = true
+	return
+}
+
+// isImageVersionMatch resolves the live pods for alias via the shared
+// informer cache & checks each container's running image tag against the
+// component's Version
+func (v *KubeClientVerifier) isImageVersionMatch(alias string) (yes bool, err error) {
+	c, err := v.componentForAnyKind(alias)
+	if err != nil {
+		return
+	}
+
+	if len(strings.TrimSpace(c.Version)) == 0 {
+		err = fmt.Errorf("unable to verify image-version-match for alias '%s': component version is required", alias)
+		return
+	}
+
+	pods, err := v.listPods(c)
+	if err != nil {
+		return
+	}
+	if len(pods) == 0 {
+		err = fmt.Errorf("unable to verify image-version-match for alias '%s': no running pods found", alias)
+		return
+	}
+
+	var images []containerImage
+	for _, pod := range pods {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if len(c.Container) > 0 && cs.Name != c.Container {
+				continue
+			}
+			images = append(images, containerImage{PodName: pod.Name, ContainerName: cs.Name, Image: cs.Image})
+		}
+	}
+
+	if err = verifyImageVersions(images, c.Version); err != nil {
+		return
+	}
+	yes = true
+	return
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import "testing"
+
+func TestImageVersionTag(t *testing.T) {
+	tests := map[string]struct {
+		image string
+		want  string
+	}{
+		"plain tag":                  {image: "nginx:1.2.3", want: "1.2.3"},
+		"tag with digest suffix":     {image: "nginx:1.2.3@sha256:abcd1234", want: "1.2.3"},
+		"registry with port and tag": {image: "registry.internal:5000/nginx:1.2.3", want: "1.2.3"},
+		"registry with port, no tag": {image: "registry.internal:5000/nginx", want: ""},
+		"namespaced image with tag":  {image: "openebs/m-apiserver:1.9.0", want: "1.9.0"},
+		"digest only, no tag":        {image: "nginx@sha256:abcd1234", want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := imageVersionTag(tt.image); got != tt.want {
+				t.Fatalf("imageVersionTag(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchImageVersion(t *testing.T) {
+	tests := map[string]struct {
+		found    string
+		expected string
+		want     bool
+	}{
+		"exact semver match":                                {found: "1.2.3", expected: "1.2.3", want: true},
+		"semver mismatch":                                   {found: "1.2.3", expected: "1.2.4", want: false},
+		"semver with v prefix matches":                      {found: "v1.2.3", expected: "1.2.3", want: true},
+		"non-semver exact string match":                     {found: "latest", expected: "latest", want: true},
+		"non-semver string mismatch":                        {found: "latest", expected: "stable", want: false},
+		"one side non-semver falls back to string equality": {found: "1.2.3", expected: "latest", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := matchImageVersion(tt.found, tt.expected); got != tt.want {
+				t.Fatalf("matchImageVersion(%q, %q) = %v, want %v", tt.found, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/AmitKumarDas/litmus/pkg/kubectl"
+)
+
+const (
+	// podPhaseCondPrefix asserts a pod's phase e.g. "pod-phase=Running"
+	podPhaseCondPrefix = "pod-phase="
+	// podReadyCond asserts every container in the pod is ready
+	podReadyCond Condition = "pod-ready=true"
+	// restartCountBelowCondPrefix asserts a container's restart count stays
+	// below N e.g. "restart-count-below=3"
+	restartCountBelowCondPrefix = "restart-count-below="
+	// containerImageEqualsCondPrefix asserts a container's running image
+	// e.g. "container-image-equals=openebs/m-apiserver:1.0.0"
+	containerImageEqualsCondPrefix = "container-image-equals="
+	// waitingReasonNotCondPrefix asserts a container is not stuck waiting on
+	// the given reason e.g. "waiting-reason-not=CrashLoopBackOff"
+	waitingReasonNotCondPrefix = "waiting-reason-not="
+)
+
+// podContainerState is a normalized view of a single container's status,
+// regardless of whether it was fetched via kubectl or client-go
+type podContainerState struct {
+	Name          string
+	Image         string
+	Ready         bool
+	RestartCount  int32
+	WaitingReason string
+}
+
+// podState is a normalized view of a single pod's status
+type podState struct {
+	Name       string
+	Phase      string
+	Containers []podContainerState
+}
+
+// isPodAssertionCondition reports whether condition is one of the
+// fine-grained pod assertions handled by this file
+func isPodAssertionCondition(condition Condition) bool {
+	s := string(condition)
+	return condition == podReadyCond ||
+		strings.HasPrefix(s, podPhaseCondPrefix) ||
+		strings.HasPrefix(s, restartCountBelowCondPrefix) ||
+		strings.HasPrefix(s, containerImageEqualsCondPrefix) ||
+		strings.HasPrefix(s, waitingReasonNotCondPrefix)
+}
+
+// evaluatePodAssertion runs the pod assertion encoded by condition against
+// states, restricting the check to a specific container when container is
+// non-empty, and returns the first concrete failure it finds
+func evaluatePodAssertion(condition Condition, container string, states []podState) (bool, error) {
+	s := string(condition)
+
+	switch {
+	case condition == podReadyCond:
+		return assertPodReady(states, container)
+	case strings.HasPrefix(s, podPhaseCondPrefix):
+		return assertPodPhase(states, strings.TrimPrefix(s, podPhaseCondPrefix))
+	case strings.HasPrefix(s, restartCountBelowCondPrefix):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, restartCountBelowCondPrefix))
+		if err != nil {
+			return false, fmt.Errorf("invalid condition '%s': %s", condition, err.Error())
+		}
+		return assertRestartCountBelow(states, container, int32(n))
+	case strings.HasPrefix(s, containerImageEqualsCondPrefix):
+		return assertContainerImageEquals(states, container, strings.TrimPrefix(s, containerImageEqualsCondPrefix))
+	case strings.HasPrefix(s, waitingReasonNotCondPrefix):
+		return assertWaitingReasonNot(states, container, strings.TrimPrefix(s, waitingReasonNotCondPrefix))
+	default:
+		return false, fmt.Errorf("condition '%s' is not supported", condition)
+	}
+}
+
+// assertPodPhase fails on the first pod whose phase does not equal expected
+func assertPodPhase(states []podState, expected string) (bool, error) {
+	for _, p := range states {
+		if p.Phase != expected {
+			return false, fmt.Errorf("pod '%s': expected phase '%s', found '%s'", p.Name, expected, p.Phase)
+		}
+	}
+	return true, nil
+}
+
+// assertPodReady fails on the first container, optionally restricted to
+// container, that is not ready
+func assertPodReady(states []podState, container string) (bool, error) {
+	for _, p := range states {
+		for _, c := range p.Containers {
+			if len(container) > 0 && c.Name != container {
+				continue
+			}
+			if !c.Ready {
+				return false, fmt.Errorf("pod '%s' container '%s': not ready", p.Name, c.Name)
+			}
+		}
+	}
+	return true, nil
+}
+
+// assertRestartCountBelow fails on the first container, optionally
+// restricted to container, whose restart count is not below max
+func assertRestartCountBelow(states []podState, container string, max int32) (bool, error) {
+	for _, p := range states {
+		for _, c := range p.Containers {
+			if len(container) > 0 && c.Name != container {
+				continue
+			}
+			if c.RestartCount >= max {
+				return false, fmt.Errorf("pod '%s' container '%s': restart count %d is not below %d", p.Name, c.Name, c.RestartCount, max)
+			}
+		}
+	}
+	return true, nil
+}
+
+// assertContainerImageEquals fails on the first container, optionally
+// restricted to container, whose running image does not equal ref
+func assertContainerImageEquals(states []podState, container string, ref string) (bool, error) {
+	for _, p := range states {
+		for _, c := range p.Containers {
+			if len(container) > 0 && c.Name != container {
+				continue
+			}
+			if c.Image != ref {
+				return false, fmt.Errorf("pod '%s' container '%s': expected image '%s', found '%s'", p.Name, c.Name, ref, c.Image)
+			}
+		}
+	}
+	return true, nil
+}
+
+// assertWaitingReasonNot fails on the first container, optionally restricted
+// to container, that is stuck waiting on reason
+func assertWaitingReasonNot(states []podState, container string, reason string) (bool, error) {
+	for _, p := range states {
+		for _, c := range p.Containers {
+			if len(container) > 0 && c.Name != container {
+				continue
+			}
+			if c.WaitingReason == reason {
+				return false, fmt.Errorf("pod '%s' container '%s': stuck waiting on reason '%s'", p.Name, c.Name, reason)
+			}
+		}
+	}
+	return true, nil
+}
+
+// isPodAssertion resolves the live pods for alias via kubectl & evaluates
+// condition against their normalized status
+func (v *KubeInstallVerify) isPodAssertion(alias string, condition Condition) (bool, error) {
+	c, err := v.getMatchingComponent(alias)
+	if err != nil {
+		return false, err
+	}
+
+	statuses, err := kubectl.GetPodStatuses(v.kubectl, c.Namespace, c.Labels)
+	if err != nil {
+		return false, err
+	}
+	if len(statuses) == 0 {
+		return false, fmt.Errorf("unable to verify condition '%s' for alias '%s': no running pods found", condition, alias)
+	}
+
+	states := make([]podState, 0, len(statuses))
+	for _, s := range statuses {
+		containers := make([]podContainerState, 0, len(s.Containers))
+		for _, cs := range s.Containers {
+			containers = append(containers, podContainerState{
+				Name:          cs.Name,
+				Image:         cs.Image,
+				Ready:         cs.Ready,
+				RestartCount:  cs.RestartCount,
+				WaitingReason: cs.WaitingReason,
+			})
+		}
+		states = append(states, podState{Name: s.PodName, Phase: s.Phase, Containers: containers})
+	}
+
+	return evaluatePodAssertion(condition, c.Container, states)
+}
+
+// isPodAssertion resolves the live pods for alias via the shared informer
+// cache & evaluates condition against their normalized status
+func (v *KubeClientVerifier) isPodAssertion(alias string, condition Condition) (bool, error) {
+	c, err := v.componentForAnyKind(alias)
+	if err != nil {
+		return false, err
+	}
+
+	pods, err := v.listPods(c)
+	if err != nil {
+		return false, err
+	}
+	if len(pods) == 0 {
+		return false, fmt.Errorf("unable to verify condition '%s' for alias '%s': no running pods found", condition, alias)
+	}
+
+	states := make([]podState, 0, len(pods))
+	for _, pod := range pods {
+		containers := make([]podContainerState, 0, len(pod.Status.ContainerStatuses))
+		for _, cs := range pod.Status.ContainerStatuses {
+			waitingReason := ""
+			if cs.State.Waiting != nil {
+				waitingReason = cs.State.Waiting.Reason
+			}
+			containers = append(containers, podContainerState{
+				Name:          cs.Name,
+				Image:         cs.Image,
+				Ready:         cs.Ready,
+				RestartCount:  cs.RestartCount,
+				WaitingReason: waitingReason,
+			})
+		}
+		states = append(states, podState{Name: pod.Name, Phase: string(pod.Status.Phase), Containers: containers})
+	}
+
+	return evaluatePodAssertion(condition, c.Container, states)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import "testing"
+
+func TestEvaluatePodAssertion(t *testing.T) {
+	states := []podState{
+		{
+			Name:  "pod-1",
+			Phase: "Running",
+			Containers: []podContainerState{
+				{Name: "main", Image: "openebs/m-apiserver:1.0.0", Ready: true, RestartCount: 1, WaitingReason: ""},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		condition Condition
+		container string
+		states    []podState
+		wantOK    bool
+		wantErr   bool
+	}{
+		"pod-phase matches":              {condition: Condition(podPhaseCondPrefix + "Running"), states: states, wantOK: true},
+		"pod-phase mismatch":             {condition: Condition(podPhaseCondPrefix + "Pending"), states: states, wantErr: true},
+		"pod-ready true":                 {condition: podReadyCond, states: states, wantOK: true},
+		"restart-count-below satisfied":  {condition: Condition(restartCountBelowCondPrefix + "3"), states: states, wantOK: true},
+		"restart-count-below violated":   {condition: Condition(restartCountBelowCondPrefix + "1"), states: states, wantErr: true},
+		"restart-count-below bad int":    {condition: Condition(restartCountBelowCondPrefix + "not-a-number"), states: states, wantErr: true},
+		"container-image-equals matches": {condition: Condition(containerImageEqualsCondPrefix + "openebs/m-apiserver:1.0.0"), states: states, wantOK: true},
+		"container-image-equals differs": {condition: Condition(containerImageEqualsCondPrefix + "openebs/m-apiserver:2.0.0"), states: states, wantErr: true},
+		"waiting-reason-not satisfied":   {condition: Condition(waitingReasonNotCondPrefix + "CrashLoopBackOff"), states: states, wantOK: true},
+		"unsupported condition":          {condition: Condition("unsupported-condition"), states: states, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ok, err := evaluatePodAssertion(tt.condition, tt.container, tt.states)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				if ok {
+					t.Fatalf("expected ok=false alongside the error, got true")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok=%v, got %v", tt.wantOK, ok)
+			}
+		})
+	}
+}
+
+func TestIsPodAssertionCondition(t *testing.T) {
+	tests := map[string]struct {
+		condition Condition
+		want      bool
+	}{
+		"pod-ready is recognized":               {condition: podReadyCond, want: true},
+		"pod-phase prefix is recognized":        {condition: Condition(podPhaseCondPrefix + "Running"), want: true},
+		"restart-count-below is recognized":     {condition: Condition(restartCountBelowCondPrefix + "3"), want: true},
+		"unrelated condition is not recognized": {condition: UniqueNodeCond, want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isPodAssertionCondition(tt.condition); got != tt.want {
+				t.Fatalf("isPodAssertionCondition(%q) = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
@@ -17,9 +17,13 @@ limitations under the License.
 package verify
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/AmitKumarDas/litmus/pkg/kubectl"
 	"github.com/ghodss/yaml"
@@ -77,6 +81,10 @@ type ConditionVerifier interface {
 // if specific entities passes the action
 type ActionVerifier interface {
 	IsAction(alias string, action Action) (yes bool, err error)
+	// IsActionWithRollback runs a chaos action against the component identified
+	// by alias and returns a RollbackHandle that the caller must invoke to
+	// guarantee cleanup of the action's side effects
+	IsActionWithRollback(alias string, action Action) (rollback RollbackHandle, err error)
 }
 
 // DeployRunVerifier provides contract(s) i.e. method signature(s) to
@@ -91,6 +99,36 @@ type DeployRunVerifier interface {
 	RunVerifier
 }
 
+// ComponentStatus reports the observed state of a single component once a
+// *Within verification returns, whether it succeeded or timed out
+type ComponentStatus struct {
+	// Alias of the component this status is for
+	Alias string `json:"alias"`
+	// Kind of the component this status is for
+	Kind string `json:"kind"`
+	// ObservedState is a short human readable state: "Deployed" or
+	// "Running" on success, "Timeout" otherwise
+	ObservedState string `json:"observedState"`
+	// Reason elaborates on ObservedState, e.g. the error that was last seen
+	Reason string `json:"reason"`
+}
+
+// DeployWithinVerifier provides contract(s) i.e. method signature(s) to
+// evaluate if an installation becomes deployed before ctx/timeout elapses
+type DeployWithinVerifier interface {
+	// IsDeployedWithin blocks until every component is deployed, or ctx is
+	// done / timeout elapses, returning a per-component status report
+	IsDeployedWithin(ctx context.Context, timeout time.Duration) (yes bool, statuses []ComponentStatus, err error)
+}
+
+// RunWithinVerifier provides contract(s) i.e. method signature(s) to
+// evaluate if an installation becomes running before ctx/timeout elapses
+type RunWithinVerifier interface {
+	// IsRunningWithin blocks until every component is running, or ctx is
+	// done / timeout elapses, returning a per-component status report
+	IsRunningWithin(ctx context.Context, timeout time.Duration) (yes bool, statuses []ComponentStatus, err error)
+}
+
 // AllVerifier provides contract(s) i.e. method signature(s) to
 // evaluate:
 //
@@ -110,6 +148,17 @@ type AllVerifier interface {
 	ActionVerifier
 }
 
+// AllWithinVerifier provides contract(s) i.e. method signature(s) to
+// evaluate both deploy & run state before ctx/timeout elapses
+type AllWithinVerifier interface {
+	// DeployWithinVerifier will check if the instance gets deployed within
+	// the given timeout
+	DeployWithinVerifier
+	// RunWithinVerifier will check if the instance gets to running state
+	// within the given timeout
+	RunWithinVerifier
+}
+
 // Installation represents a set of components that represent an installation
 // e.g. an operator represented by its CRDs, RBACs and Deployments forms an
 // installation
@@ -121,6 +170,22 @@ type Installation struct {
 	Version string `json:"version"`
 	// Components of this installation
 	Components []Component `json:"components"`
+	// Parameters documents the knobs this verify file accepts via its values
+	// map e.g. namespace, image tag, replica count
+	Parameters []Parameter `json:"parameters"`
+}
+
+// Parameter documents a single knob that a verify file accepts via its
+// values map, so the file is self describing instead of relying on
+// hardcoded settings
+type Parameter struct {
+	// Name of the parameter, referenced in the verify file as {{.Name}}
+	Name string `json:"name"`
+	// Default value used when the values map does not set this parameter
+	Default string `json:"default"`
+	// Required marks that load should fail fast if this parameter is absent
+	// from the values map & has no Default
+	Required bool `json:"required"`
 }
 
 // Component is the information about a particular component
@@ -149,6 +214,20 @@ type Component struct {
 	// NOTE:
 	//  Ensure unique alias values in an installation
 	Alias string `json:"alias"`
+	// Container is the name of the specific container to target within the
+	// component's pod(s). Optional; required only for multi-container pods.
+	Container string `json:"container"`
+	// Image is the expected container image reference for this component.
+	// The image-version-match condition does not read it; it compares the
+	// running image tag against Version only.
+	Image string `json:"image"`
+	// Version is the expected version of this component, compared against
+	// the tag portion of the running container's image by the
+	// image-version-match condition
+	Version string `json:"version"`
+	// ChaosParams carries the parameters required to run a chaos action
+	// against this component e.g. duration, percentage load, target process
+	ChaosParams *ChaosParams `json:"chaosParams"`
 }
 
 // unmarshal takes the raw yaml data and unmarshals it into Installation
@@ -161,6 +240,14 @@ func unmarshal(data []byte) (installation *Installation, err error) {
 
 // load converts a verify file into an instance of *Installation
 func load(file VerifyFile) (installation *Installation, err error) {
+	return loadWithValues(file, nil)
+}
+
+// loadWithValues converts a verify file into an instance of *Installation,
+// rendering the file as a text/template against values before unmarshalling.
+// It fails fast if the verify file declares a required Parameter that is
+// absent from values & has no Default.
+func loadWithValues(file VerifyFile, values map[string]interface{}) (installation *Installation, err error) {
 	if len(file) == 0 {
 		err = fmt.Errorf("failed to load: verify file is not provided")
 		return
@@ -171,7 +258,151 @@ func load(file VerifyFile) (installation *Installation, err error) {
 		return
 	}
 
-	return unmarshal(d)
+	// the verify file as a whole cannot be unmarshalled ahead of rendering:
+	// an unquoted {{.Name}} in a yaml value position parses as the start of a
+	// flow mapping and fails. Its "parameters" section never contains
+	// template syntax though, so pull just that block out ahead of rendering,
+	// purely to discover which parameters have defaults that need merging
+	// into values
+	declared, err := declaredParameters(d)
+	if err != nil {
+		return
+	}
+
+	rendered, err := renderTemplate(d, withDefaults(declared, values))
+	if err != nil {
+		err = fmt.Errorf("failed to load '%s': %s", file, err.Error())
+		return
+	}
+
+	installation, err = unmarshal(rendered)
+	if err != nil {
+		return
+	}
+
+	err = validateRequiredParameters(installation, values)
+	return
+}
+
+// withDefaults returns a copy of values with every declared Parameter's
+// Default filled in for parameters values does not already set, so a
+// template can rely on {{.Name}} resolving even when the caller omitted an
+// optional parameter
+func withDefaults(declared []Parameter, values map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range values {
+		merged[k] = v
+	}
+
+	for _, p := range declared {
+		if _, ok := merged[p.Name]; ok {
+			continue
+		}
+		if len(p.Default) == 0 {
+			continue
+		}
+		merged[p.Name] = p.Default
+	}
+
+	return merged
+}
+
+// declaredParameters extracts the verify file's top-level "parameters:"
+// block & unmarshals just that block, so defaults can be discovered before
+// the file is rendered as a template. Unlike the full file, the parameters
+// block is plain, non-templated yaml & is safe to unmarshal directly; the
+// rest of the file may contain unquoted {{ }} template syntax in a yaml
+// value position, which a full unmarshal would choke on before rendering
+// ever gets a chance to resolve it.
+func declaredParameters(data []byte) ([]Parameter, error) {
+	block := extractTopLevelBlock(data, "parameters:")
+	if len(block) == 0 {
+		return nil, nil
+	}
+
+	var holder struct {
+		Parameters []Parameter `json:"parameters"`
+	}
+	if err := yaml.Unmarshal(block, &holder); err != nil {
+		return nil, err
+	}
+	return holder.Parameters, nil
+}
+
+// extractTopLevelBlock returns the lines starting at the first top-level
+// (non-indented) line beginning with prefix, up to but not including the
+// next top-level line, or the end of data if prefix is the last top-level
+// block
+func extractTopLevelBlock(data []byte, prefix string) []byte {
+	lines := strings.Split(string(data), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '-' {
+			continue
+		}
+		end = i
+		break
+	}
+
+	return []byte(strings.Join(lines[start:end], "\n"))
+}
+
+// renderTemplate renders the verify file's raw bytes as a text/template
+// against the provided values, so a single file can cover multiple
+// installations by varying e.g. namespace, image tag, replica count
+func renderTemplate(data []byte, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New("verifyfile").Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// validateRequiredParameters fails fast if the Installation declares a
+// required Parameter that is neither set in values nor given a Default
+func validateRequiredParameters(installation *Installation, values map[string]interface{}) error {
+	if installation == nil {
+		return nil
+	}
+
+	for _, p := range installation.Parameters {
+		if !p.Required {
+			continue
+		}
+
+		if _, ok := values[p.Name]; ok {
+			continue
+		}
+
+		if len(strings.TrimSpace(p.Default)) > 0 {
+			continue
+		}
+
+		return fmt.Errorf("required parameter '%s' is missing a value & has no default", p.Name)
+	}
+
+	return nil
 }
 
 // KubeInstallVerify provides methods that handles verification related logic of
@@ -181,24 +412,65 @@ type KubeInstallVerify struct {
 	installation *Installation
 	// kubectl enables execution of kubernetes operations
 	kubectl kubectl.KubeRunner
+	// client is the client-go backed verifier this instance delegates to when
+	// the provided kubectl.KubeRunner also implements RESTConfigProvider. When
+	// nil, all verification falls back to shelling out via kubectl.
+	client *KubeClientVerifier
 }
 
 // NewKubeInstallVerify provides a new instance of NewKubeInstallVerify based on
-// the provided kubernetes runner & verify file
+// the provided kubernetes runner & verify file.
+//
+// When runner also implements RESTConfigProvider, verification delegates to a
+// client-go backed KubeClientVerifier instead of shelling out to kubectl.
 func NewKubeInstallVerify(runner kubectl.KubeRunner, file VerifyFile) (*KubeInstallVerify, error) {
 	i, err := load(file)
 	if err != nil {
 		return nil, err
 	}
 
-	return &KubeInstallVerify{
+	return newKubeInstallVerify(runner, i)
+}
+
+// NewKubeInstallVerifyWithValues is like NewKubeInstallVerify, but first
+// renders the verify file as a text/template against values, allowing one
+// verify file to cover multiple installations e.g. across OpenEBS releases.
+func NewKubeInstallVerifyWithValues(runner kubectl.KubeRunner, file VerifyFile, values map[string]interface{}) (*KubeInstallVerify, error) {
+	i, err := loadWithValues(file, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKubeInstallVerify(runner, i)
+}
+
+// newKubeInstallVerify wires up a KubeInstallVerify against an already
+// loaded Installation, delegating to a client-go backed KubeClientVerifier
+// when runner also implements RESTConfigProvider
+func newKubeInstallVerify(runner kubectl.KubeRunner, i *Installation) (*KubeInstallVerify, error) {
+	v := &KubeInstallVerify{
 		kubectl:      runner,
 		installation: i,
-	}, nil
+	}
+
+	if provider, ok := runner.(RESTConfigProvider); ok {
+		cfg, cerr := provider.RESTConfig()
+		if cerr == nil {
+			if cv, cverr := newKubeClientVerifierFromConfig(cfg, i); cverr == nil {
+				v.client = cv
+			}
+		}
+	}
+
+	return v, nil
 }
 
 // IsDeployed evaluates if all components of the installation are deployed
 func (v *KubeInstallVerify) IsDeployed() (yes bool, err error) {
+	if v.client != nil {
+		return v.client.IsDeployed()
+	}
+
 	if v.installation == nil {
 		err = fmt.Errorf("failed to check IsDeployed: installation object is nil")
 		return
@@ -216,6 +488,10 @@ func (v *KubeInstallVerify) IsDeployed() (yes bool, err error) {
 
 // IsRunning evaluates if all components of the installation are running
 func (v *KubeInstallVerify) IsRunning() (yes bool, err error) {
+	if v.client != nil {
+		return v.client.IsRunning()
+	}
+
 	if v.installation == nil {
 		err = fmt.Errorf("failed to check IsRunning: installation object is nil")
 		return
@@ -233,9 +509,17 @@ func (v *KubeInstallVerify) IsRunning() (yes bool, err error) {
 
 // IsCondition evaluates if specific components satisfies the condition
 func (v *KubeInstallVerify) IsCondition(alias string, condition Condition) (yes bool, err error) {
-	switch condition {
-	case UniqueNodeCond:
+	if v.client != nil {
+		return v.client.IsCondition(alias, condition)
+	}
+
+	switch {
+	case condition == UniqueNodeCond:
 		return v.isEachComponentOnUniqueNode(alias)
+	case condition == ImageVersionMatchCond:
+		return v.isImageVersionMatch(alias)
+	case isPodAssertionCondition(condition):
+		return v.isPodAssertion(alias, condition)
 	default:
 		err = fmt.Errorf("condition '%s' is not supported", condition)
 	}
@@ -244,6 +528,10 @@ func (v *KubeInstallVerify) IsCondition(alias string, condition Condition) (yes
 
 // IsAction evaluates if specific components satisfies the action
 func (v *KubeInstallVerify) IsAction(alias string, action Action) (yes bool, err error) {
+	if v.client != nil {
+		return v.client.IsAction(alias, action)
+	}
+
 	switch action {
 	case DeleteAnyPodAction:
 		return v.isDeleteAnyRunningPod(alias)
@@ -349,6 +637,32 @@ func (v *KubeInstallVerify) getMatchingPodComponent(alias string) (comp Componen
 	return filtered[0], nil
 }
 
+// getMatchingComponent returns the single component matching alias,
+// regardless of Kind. Unlike getMatchingPodComponent, this also matches
+// non-pod kinds (e.g. Deployment, StatefulSet, DaemonSet) whose pods are
+// still reachable via the component's label selector.
+func (v *KubeInstallVerify) getMatchingComponent(alias string) (comp Component, err error) {
+	var filtered = []Component{}
+
+	for _, c := range v.installation.Components {
+		if c.Alias == alias {
+			filtered = append(filtered, c)
+		}
+	}
+
+	if len(filtered) == 0 {
+		err = fmt.Errorf("component not found for alias '%s'", alias)
+		return
+	}
+
+	if len(filtered) > 1 {
+		err = fmt.Errorf("multiple components found for alias '%s': alias should be unique in an install", alias)
+		return
+	}
+
+	return filtered[0], nil
+}
+
 // isComponentDeployed flags if a particular component is deployed
 func (v *KubeInstallVerify) isComponentDeployed(component Component) (yes bool, err error) {
 	return kubectl.IsResourceDeployed(v.kubectl, component.Kind, component.Name, component.Namespace, component.Labels)
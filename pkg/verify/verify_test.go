@@ -0,0 +1,165 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		values  map[string]interface{}
+		want    string
+		wantErr bool
+	}{
+		"substitutes a provided value": {
+			data:   "namespace: {{.Namespace}}",
+			values: map[string]interface{}{"Namespace": "litmus"},
+			want:   "namespace: litmus",
+		},
+		"missing value renders the zero value placeholder": {
+			data:   "namespace: {{.Namespace}}",
+			values: map[string]interface{}{},
+			want:   "namespace: <no value>",
+		},
+		"invalid template syntax errors": {
+			data:    "namespace: {{.Namespace",
+			values:  map[string]interface{}{},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := renderTemplate([]byte(tt.data), tt.values)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+			if string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, string(got))
+			}
+		})
+	}
+}
+
+func TestValidateRequiredParameters(t *testing.T) {
+	tests := map[string]struct {
+		installation *Installation
+		values       map[string]interface{}
+		wantErr      bool
+	}{
+		"nil installation is valid": {
+			installation: nil,
+		},
+		"required parameter set in values": {
+			installation: &Installation{Parameters: []Parameter{{Name: "Namespace", Required: true}}},
+			values:       map[string]interface{}{"Namespace": "litmus"},
+		},
+		"required parameter covered by a default": {
+			installation: &Installation{Parameters: []Parameter{{Name: "Namespace", Required: true, Default: "default"}}},
+		},
+		"required parameter missing from values and no default": {
+			installation: &Installation{Parameters: []Parameter{{Name: "Namespace", Required: true}}},
+			wantErr:      true,
+		},
+		"optional parameter missing from values is fine": {
+			installation: &Installation{Parameters: []Parameter{{Name: "Namespace"}}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateRequiredParameters(tt.installation, tt.values)
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestWithDefaults(t *testing.T) {
+	declared := []Parameter{
+		{Name: "Namespace", Default: "litmus"},
+		{Name: "Replicas", Default: "1"},
+	}
+
+	merged := withDefaults(declared, map[string]interface{}{"Replicas": "3"})
+
+	if merged["Namespace"] != "litmus" {
+		t.Fatalf("expected default 'litmus' to be merged for Namespace, got %v", merged["Namespace"])
+	}
+	if merged["Replicas"] != "3" {
+		t.Fatalf("expected caller-provided value '3' to win over the default for Replicas, got %v", merged["Replicas"])
+	}
+}
+
+func TestDeclaredParameters(t *testing.T) {
+	data := "verifyID: test\n" +
+		"parameters:\n" +
+		"- name: Namespace\n" +
+		"  default: litmus\n" +
+		"components:\n" +
+		"- name: {{.Namespace}}-operator\n" +
+		"  namespace: {{.Namespace}}\n"
+
+	params, err := declaredParameters([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(params) != 1 || params[0].Name != "Namespace" || params[0].Default != "litmus" {
+		t.Fatalf("expected a single Namespace parameter defaulting to 'litmus', got %+v", params)
+	}
+}
+
+func TestLoadWithValuesAppliesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	file := dir + "/install.yaml"
+	data := "verifyID: test\n" +
+		"parameters:\n" +
+		"- name: Namespace\n" +
+		"  default: litmus\n" +
+		"components:\n" +
+		"- name: {{.Namespace}}-operator\n" +
+		"  namespace: {{.Namespace}}\n"
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %s", err.Error())
+	}
+
+	installation, err := loadWithValues(VerifyFile(file), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if installation.Components[0].Namespace != "litmus" {
+		t.Fatalf("expected default 'litmus' to render into namespace, got %q", installation.Components[0].Namespace)
+	}
+	if strings.Contains(installation.Components[0].Name, "<no value>") {
+		t.Fatalf("default was not merged before templating: %q", installation.Components[0].Name)
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	observedStateDeployed = "Deployed"
+	observedStateRunning  = "Running"
+	observedStateTimeout  = "Timeout"
+
+	// watchPollInterval is how often a component is re-checked while its
+	// worker waits for the shared informer cache to observe the desired state
+	watchPollInterval = 200 * time.Millisecond
+)
+
+// IsDeployedWithin blocks until every component is deployed, or ctx is done
+// / timeout elapses. Each component is checked concurrently via a workqueue.
+// Pod components are read from the shared informer cache; components of
+// other kinds are listed through the dynamic client on every check.
+func (v *KubeClientVerifier) IsDeployedWithin(ctx context.Context, timeout time.Duration) (bool, []ComponentStatus, error) {
+	return v.watchComponents(ctx, timeout, observedStateDeployed, v.isComponentDeployed)
+}
+
+// IsRunningWithin blocks until every component is running, or ctx is done /
+// timeout elapses. Each component is checked concurrently via a workqueue
+// backed by the shared informer cache.
+func (v *KubeClientVerifier) IsRunningWithin(ctx context.Context, timeout time.Duration) (bool, []ComponentStatus, error) {
+	return v.watchComponents(ctx, timeout, observedStateRunning, v.isPodComponentRunning)
+}
+
+// watchComponents enqueues every component onto a workqueue & fans workers
+// out across it, each re-running checkFn for its component every
+// watchPollInterval until checkFn reports success or ctx is done
+func (v *KubeClientVerifier) watchComponents(ctx context.Context, timeout time.Duration, successState string, checkFn func(Component) (bool, error)) (bool, []ComponentStatus, error) {
+	if v.installation == nil {
+		return false, nil, fmt.Errorf("failed to watch: installation object is nil")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	components := v.installation.Components
+	statuses := make([]ComponentStatus, len(components))
+
+	queue := workqueue.NewNamed("verify-components")
+	defer queue.ShutDown()
+	for i := range components {
+		queue.Add(i)
+	}
+	// every item is already enqueued above, so shutting down now is safe:
+	// Get() keeps draining the queued items and only reports shutdown=true
+	// once it is empty. Without this, workers block on Get() forever once
+	// they run out of items, and watchComponents always blocks for the full
+	// timeout even when every component is already healthy.
+	queue.ShutDown()
+
+	var wg sync.WaitGroup
+	numWorkers := len(components)
+	if numWorkers == 0 {
+		return true, statuses, nil
+	}
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, shutdown := queue.Get()
+				if shutdown {
+					return
+				}
+
+				idx := item.(int)
+				statuses[idx] = v.awaitComponent(ctx, components[idx], successState, checkFn)
+				queue.Done(item)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		<-done
+	}
+
+	yes := true
+	for _, s := range statuses {
+		if s.ObservedState != successState {
+			yes = false
+		}
+	}
+
+	return yes, statuses, nil
+}
+
+// awaitComponent polls checkFn until it succeeds or ctx is done, translating
+// the outcome into a ComponentStatus
+func (v *KubeClientVerifier) awaitComponent(ctx context.Context, c Component, successState string, checkFn func(Component) (bool, error)) ComponentStatus {
+	status := ComponentStatus{Alias: c.Alias, Kind: c.Kind}
+
+	var lastErr error
+	err := wait.PollImmediateUntil(watchPollInterval, func() (bool, error) {
+		yes, cerr := checkFn(c)
+		lastErr = cerr
+		return yes && cerr == nil, nil
+	}, ctx.Done())
+
+	if err != nil {
+		status.ObservedState = observedStateTimeout
+		if lastErr != nil {
+			status.Reason = lastErr.Error()
+		} else {
+			status.Reason = ctx.Err().Error()
+		}
+		return status
+	}
+
+	status.ObservedState = successState
+	return status
+}
+
+// IsDeployedWithin blocks until every component is deployed, or ctx is done
+// / timeout elapses. Delegates to the client-go watch-based implementation
+// when available, otherwise falls back to a bounded-backoff list-poll over
+// the legacy kubectl.KubeRunner.
+func (v *KubeInstallVerify) IsDeployedWithin(ctx context.Context, timeout time.Duration) (bool, []ComponentStatus, error) {
+	if v.client != nil {
+		return v.client.IsDeployedWithin(ctx, timeout)
+	}
+	return v.pollWithin(ctx, timeout, observedStateDeployed, v.isComponentDeployed)
+}
+
+// IsRunningWithin blocks until every component is running, or ctx is done /
+// timeout elapses. Delegates to the client-go watch-based implementation
+// when available, otherwise falls back to a bounded-backoff list-poll over
+// the legacy kubectl.KubeRunner.
+func (v *KubeInstallVerify) IsRunningWithin(ctx context.Context, timeout time.Duration) (bool, []ComponentStatus, error) {
+	if v.client != nil {
+		return v.client.IsRunningWithin(ctx, timeout)
+	}
+	return v.pollWithin(ctx, timeout, observedStateRunning, v.isPodComponentRunning)
+}
+
+// pollWithin is the legacy kubectl.KubeRunner fallback: each component is
+// polled with a bounded exponential backoff until checkFn succeeds or its
+// own share of timeout elapses, one component at a time since
+// kubectl.KubeRunner forks a process per call & does not support concurrent
+// watches. timeout is split evenly across components so that a component
+// stuck for its whole backoff budget cannot starve the components checked
+// after it; without this, every later component would see ctx already done
+// and be reported Timeout despite never having been polled.
+func (v *KubeInstallVerify) pollWithin(ctx context.Context, timeout time.Duration, successState string, checkFn func(Component) (bool, error)) (bool, []ComponentStatus, error) {
+	if v.installation == nil {
+		return false, nil, fmt.Errorf("failed to poll: installation object is nil")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	components := v.installation.Components
+	statuses := make([]ComponentStatus, len(components))
+	yes := true
+
+	perComponentTimeout := timeout
+	if n := len(components); n > 0 {
+		perComponentTimeout = timeout / time.Duration(n)
+	}
+
+	for i, c := range components {
+		status := ComponentStatus{Alias: c.Alias, Kind: c.Kind}
+
+		compCtx, compCancel := context.WithTimeout(ctx, perComponentTimeout)
+
+		backoff := wait.Backoff{Duration: 200 * time.Millisecond, Factor: 2, Steps: 10, Cap: 10 * time.Second}
+		var lastErr error
+		err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+			if compCtx.Err() != nil {
+				return false, compCtx.Err()
+			}
+			ok, cerr := checkFn(c)
+			lastErr = cerr
+			return ok && cerr == nil, nil
+		})
+		compCancel()
+
+		if err != nil {
+			yes = false
+			status.ObservedState = observedStateTimeout
+			if lastErr != nil {
+				status.Reason = lastErr.Error()
+			} else {
+				status.Reason = err.Error()
+			}
+		} else {
+			status.ObservedState = successState
+		}
+
+		statuses[i] = status
+	}
+
+	return yes, statuses, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The OpenEBS Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// alwaysRunningCheck is a checkFn stub that reports success on the first call
+func alwaysRunningCheck(Component) (bool, error) {
+	return true, nil
+}
+
+func TestWatchComponentsReturnsAsSoonAsEverySuccessIsObserved(t *testing.T) {
+	installation := &Installation{Components: []Component{
+		{Alias: "a"}, {Alias: "b"}, {Alias: "c"},
+	}}
+	v := &KubeClientVerifier{installation: installation}
+
+	const timeout = 5 * time.Second
+	start := time.Now()
+	yes, statuses, err := v.watchComponents(context.Background(), timeout, observedStateRunning, alwaysRunningCheck)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !yes {
+		t.Fatalf("expected every component to report success")
+	}
+	for _, s := range statuses {
+		if s.ObservedState != observedStateRunning {
+			t.Fatalf("expected ObservedState %q, got %q for alias %q", observedStateRunning, s.ObservedState, s.Alias)
+		}
+	}
+	// the regression this guards against is watchComponents always blocking
+	// for the full timeout even when every component is already healthy
+	if elapsed >= timeout {
+		t.Fatalf("watchComponents took %s, expected it to return well before the %s timeout", elapsed, timeout)
+	}
+}
+
+func TestWatchComponentsReportsTimeoutWhenCheckNeverSucceeds(t *testing.T) {
+	installation := &Installation{Components: []Component{{Alias: "a"}}}
+	v := &KubeClientVerifier{installation: installation}
+
+	neverRunning := func(Component) (bool, error) {
+		return false, nil
+	}
+
+	yes, statuses, err := v.watchComponents(context.Background(), 300*time.Millisecond, observedStateRunning, neverRunning)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if yes {
+		t.Fatalf("expected overall success to be false")
+	}
+	if statuses[0].ObservedState != observedStateTimeout {
+		t.Fatalf("expected ObservedState %q, got %q", observedStateTimeout, statuses[0].ObservedState)
+	}
+}
+
+func TestPollWithinGivesEveryComponentItsOwnTimeoutShare(t *testing.T) {
+	installation := &Installation{Components: []Component{
+		{Alias: "a"}, {Alias: "b"},
+	}}
+	v := &KubeInstallVerify{installation: installation}
+
+	var calls int32
+	neverSucceeds := func(Component) (bool, error) {
+		atomic.AddInt32(&calls, 1)
+		return false, nil
+	}
+
+	_, statuses, err := v.pollWithin(context.Background(), 400*time.Millisecond, observedStateRunning, neverSucceeds)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("expected 2 statuses, got %d", len(statuses))
+	}
+	for _, s := range statuses {
+		if s.ObservedState != observedStateTimeout {
+			t.Fatalf("expected ObservedState %q for alias %q, got %q", observedStateTimeout, s.Alias, s.ObservedState)
+		}
+	}
+	// the regression this guards against is the second component never being
+	// polled at all because the first component consumed the whole shared
+	// timeout; both must have been checked at least once
+	if atomic.LoadInt32(&calls) < 2 {
+		t.Fatalf("expected both components to be polled at least once, checkFn was called %d times", calls)
+	}
+}
+
+func TestPollWithinSucceedsImmediately(t *testing.T) {
+	installation := &Installation{Components: []Component{{Alias: "a"}, {Alias: "b"}}}
+	v := &KubeInstallVerify{installation: installation}
+
+	yes, statuses, err := v.pollWithin(context.Background(), 2*time.Second, observedStateDeployed, alwaysRunningCheck)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !yes {
+		t.Fatalf("expected overall success to be true")
+	}
+	for _, s := range statuses {
+		if s.ObservedState != observedStateDeployed {
+			t.Fatalf("expected ObservedState %q for alias %q, got %q", observedStateDeployed, s.Alias, s.ObservedState)
+		}
+	}
+}